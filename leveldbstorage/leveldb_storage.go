@@ -0,0 +1,48 @@
+// Package leveldbstorage is a merkle_tree.Storage backed by LevelDB, for
+// persisting trees larger than comfortably fits in memory.
+package leveldbstorage
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+
+	merkletree "github.com/IronGauntlets/merkle-tree"
+)
+
+// LevelDBStorage persists nodes in a LevelDB database, keyed by their hash.
+type LevelDBStorage struct {
+	db *leveldb.DB
+}
+
+// Open opens (creating it if necessary) the LevelDB database at path for
+// use as a merkle_tree.Storage backend.
+func Open(path string) (*LevelDBStorage, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBStorage{db: db}, nil
+}
+
+func (s *LevelDBStorage) Get(hash [32]byte) ([]byte, error) {
+	data, err := s.db.Get(hash[:], nil)
+	if err == leveldb.ErrNotFound {
+		return nil, merkletree.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *LevelDBStorage) Put(hash [32]byte, data []byte) error {
+	return s.db.Put(hash[:], data, nil)
+}
+
+func (s *LevelDBStorage) Delete(hash [32]byte) error {
+	return s.db.Delete(hash[:], nil)
+}
+
+// Close releases the underlying database handle.
+func (s *LevelDBStorage) Close() error {
+	return s.db.Close()
+}
@@ -9,8 +9,47 @@ var HashFn = func(date []byte) [32]byte {
 	return sha256.Sum256(date)
 }
 
+// LeafHashFn and NodeHashFn are the primitives used to hash leaves and
+// internal node concatenations respectively. They default to the same
+// sha256 as HashFn but can be swapped independently (e.g. for Keccak,
+// Blake2, Poseidon) without touching HashFn itself.
+var LeafHashFn = func(data []byte) [32]byte {
+	return sha256.Sum256(data)
+}
+
+var NodeHashFn = func(data []byte) [32]byte {
+	return sha256.Sum256(data)
+}
+
 var ErrIndexOutOfRange = errors.New("index out of range")
 
+// Options configures the hashing scheme used by NewWithOptions.
+type Options struct {
+	// RFC6962 prepends a 0x00 byte before hashing leaves and a 0x01 byte
+	// before hashing internal node concatenations, matching the
+	// Certificate Transparency spec. Without it, a leaf's hash can be
+	// presented as an internal node's hash (or vice versa), the classic
+	// second-preimage attack against naive Merkle trees.
+	RFC6962 bool
+}
+
+// leafHash and nodeHash apply RFC6962's domain-separation tag bytes, if
+// requested, before delegating to LeafHashFn/NodeHashFn.
+func leafHash(data []byte, rfc6962 bool) [32]byte {
+	if !rfc6962 {
+		return LeafHashFn(data)
+	}
+	return LeafHashFn(append([]byte{0x00}, data...))
+}
+
+func nodeHash(left, right [32]byte, rfc6962 bool) [32]byte {
+	data := append(left[:], right[:]...)
+	if !rfc6962 {
+		return NodeHashFn(data)
+	}
+	return NodeHashFn(append([]byte{0x01}, data...))
+}
+
 type Node struct {
 	Hash  [32]byte
 	Left  *Node
@@ -20,58 +59,189 @@ type Node struct {
 type MerkleTree struct {
 	Root   *Node
 	Leaves []*Node
+
+	// levels caches every intermediate row of the tree, levels[0] being
+	// Leaves and levels[len(levels)-1] holding only Root. It lets AddLeaf
+	// and UpdateLeaf rehash just the O(log n) path touched by a mutation
+	// instead of rebuilding the whole tree.
+	levels [][]*Node
+
+	// storage is set by Load and NewWithStorage. While Leaves is still nil
+	// (a tree from Load), GenerateProof fetches just the nodes on a leaf's
+	// path from it, and ensureLoaded fetches Leaves the first time another
+	// call needs them, instead of requiring the whole tree in memory.
+	storage Storage
+
+	// rfc6962 records whether this tree was built with Options{RFC6962:
+	// true}, so mutations that rehash part of the tree (AddLeaf,
+	// UpdateLeaf, RemoveLeaf) keep using the same domain-separation rule
+	// it was built with.
+	rfc6962 bool
 }
 
 type MerkleProof struct {
-	i      int
-	Hashes [][32]byte
+	I       int
+	Hashes  [][32]byte
+	RFC6962 bool
 }
 
 func New(leaves [][]byte) *MerkleTree {
+	return NewWithOptions(leaves, Options{})
+}
+
+// NewWithOptions is like New but lets the caller configure the hashing
+// scheme, e.g. Options{RFC6962: true} to guard against second-preimage
+// attacks.
+func NewWithOptions(leaves [][]byte, opts Options) *MerkleTree {
 	if len(leaves) == 0 {
 		return nil
 	}
 
 	var leafNodes []*Node
 	for _, leaf := range leaves {
-		leafNodes = append(leafNodes, &Node{Hash: HashFn(leaf)})
+		leafNodes = append(leafNodes, &Node{Hash: leafHash(leaf, opts.RFC6962)})
 	}
 
-	return buildTree(leafNodes)
+	return buildTree(leafNodes, opts.RFC6962)
 }
 
 func (m *MerkleTree) RootHash() [32]byte { return m.Root.Hash }
 
-func (m *MerkleTree) AddLeaf(leaf []byte) {
-	m.Leaves = append(m.Leaves, &Node{Hash: HashFn(leaf)})
-	m.Root = buildTree(m.Leaves).Root
+// AddLeaf appends a leaf and extends the rightmost path of the cached tree
+// in O(log n): it walks up from the new leaf, forwarding it unpaired through
+// every level that was already odd-promoted (mirroring buildTree's rule) and
+// hashing it with the first sibling it meets, instead of rebuilding the tree.
+func (m *MerkleTree) AddLeaf(leaf []byte) error {
+	if err := m.ensureLoaded(); err != nil {
+		return err
+	}
+
+	node := &Node{Hash: leafHash(leaf, m.rfc6962)}
+	if len(m.levels) == 0 {
+		m.Leaves = append(m.Leaves, node)
+		m.levels = [][]*Node{m.Leaves}
+		m.Root = node
+		return nil
+	}
+
+	// extendPath appends node to levels[0] itself, so Leaves must not be
+	// appended to separately here or the new leaf ends up counted twice.
+	m.extendPath(0, node, true)
+	m.Leaves = m.levels[0]
+	return nil
 }
 
+// extendPath inserts node into levels[level], either as a newly appended
+// entry (isAppend) or replacing the level's last entry, and propagates the
+// resulting pairing or odd-promotion up to the root.
+func (m *MerkleTree) extendPath(level int, node *Node, isAppend bool) {
+	for {
+		if level == len(m.levels) {
+			m.levels = append(m.levels, []*Node{node})
+			m.Root = node
+			return
+		}
+
+		if isAppend {
+			m.levels[level] = append(m.levels[level], node)
+		} else {
+			m.levels[level][len(m.levels[level])-1] = node
+		}
+
+		lvl := m.levels[level]
+		n := len(lvl)
+
+		if n == 1 && level == len(m.levels)-1 {
+			m.Root = lvl[0]
+			return
+		}
+
+		if n%2 == 1 {
+			// No sibling yet: promote as-is, same as buildTree's odd rule.
+			node = lvl[n-1]
+			level++
+			continue
+		}
+
+		left, right := lvl[n-2], lvl[n-1]
+		node = &Node{Hash: nodeHash(left.Hash, right.Hash, m.rfc6962), Left: left, Right: right}
+		isAppend = false
+		level++
+	}
+}
+
+// UpdateLeaf replaces the leaf at i and rehashes only the path from it up to
+// the root, using the cached levels instead of rebuilding the whole tree.
 func (m *MerkleTree) UpdateLeaf(i int, leaf []byte) error {
+	if err := m.ensureLoaded(); err != nil {
+		return err
+	}
 	if i < 0 || i >= len(m.Leaves) {
 		return ErrIndexOutOfRange
 	}
-	m.Leaves[i] = &Node{Hash: HashFn(leaf)}
-	m.Root = buildTree(m.Leaves).Root
+
+	node := &Node{Hash: leafHash(leaf, m.rfc6962)}
+	m.Leaves[i] = node
+	m.levels[0] = m.Leaves
+	m.rehashPath(i)
 	return nil
 }
 
+// rehashPath recomputes every ancestor of levels[0][i], following the same
+// left-to-right pairing (and odd-promotion) that buildTree uses so the
+// resulting root is identical to a full rebuild.
+func (m *MerkleTree) rehashPath(i int) {
+	idx := i
+	for level := 0; level+1 < len(m.levels); level++ {
+		cur := m.levels[level]
+		n := len(cur)
+
+		if idx == n-1 && n%2 == 1 {
+			m.levels[level+1][idx/2] = cur[idx]
+			idx /= 2
+			continue
+		}
+
+		siblingIdx := idx ^ 1
+		left, right := cur[idx], cur[siblingIdx]
+		if idx%2 == 1 {
+			left, right = cur[siblingIdx], cur[idx]
+		}
+
+		parentIdx := idx / 2
+		m.levels[level+1][parentIdx] = &Node{Hash: nodeHash(left.Hash, right.Hash, m.rfc6962), Left: left, Right: right}
+		idx = parentIdx
+	}
+
+	m.Root = m.levels[len(m.levels)-1][0]
+}
+
 func (m *MerkleTree) RemoveLeaf(i int) error {
+	if err := m.ensureLoaded(); err != nil {
+		return err
+	}
 	if i < 0 || i >= len(m.Leaves) {
 		return ErrIndexOutOfRange
 	}
 
+	// Removing a leaf shifts every position to its right, so the O(log n)
+	// path trick doesn't apply here: fall back to a full rebuild.
 	m.Leaves = append(m.Leaves[:i], m.Leaves[i+1:]...)
-	m.Root = buildTree(m.Leaves).Root
+	tree := buildTree(m.Leaves, m.rfc6962)
+	m.Root, m.levels = tree.Root, tree.levels
 	return nil
 }
 
 func (m *MerkleTree) GenerateProof(i int) (*MerkleProof, error) {
+	if m.Leaves == nil && m.storage != nil {
+		return m.generateProofFromStorage(i)
+	}
+
 	if i < 0 || i >= len(m.Leaves) {
 		return nil, ErrIndexOutOfRange
 	}
 
-	proof := &MerkleProof{i: i}
+	proof := &MerkleProof{I: i, RFC6962: m.rfc6962}
 	nodes := m.Leaves
 
 	for len(nodes) > 1 {
@@ -79,7 +249,7 @@ func (m *MerkleTree) GenerateProof(i int) (*MerkleProof, error) {
 		var newI int
 
 		for j := 0; j < len(nodes)-1; j += 2 {
-			parentHash := HashFn(append(nodes[j].Hash[:], nodes[j+1].Hash[:]...))
+			parentHash := nodeHash(nodes[j].Hash, nodes[j+1].Hash, m.rfc6962)
 			parent := &Node{Hash: parentHash, Left: nodes[j], Right: nodes[j+1]}
 			level = append(level, parent)
 
@@ -112,12 +282,13 @@ func (m *MerkleTree) GenerateProof(i int) (*MerkleProof, error) {
 }
 
 // buildTree generates a merkle tree from the leaves. If the leaves are odd, the last leave is not duplicated.
-func buildTree(leaves []*Node) *MerkleTree {
+func buildTree(leaves []*Node, rfc6962 bool) *MerkleTree {
 	nodes := leaves
+	levels := [][]*Node{nodes}
 	for len(nodes) > 1 {
 		var level []*Node
 		for i := 0; i < len(nodes)-1; i += 2 {
-			parentHash := HashFn(append(nodes[i].Hash[:], nodes[i+1].Hash[:]...))
+			parentHash := nodeHash(nodes[i].Hash, nodes[i+1].Hash, rfc6962)
 			parent := &Node{Hash: parentHash, Left: nodes[i], Right: nodes[i+1]}
 			level = append(level, parent)
 		}
@@ -128,13 +299,17 @@ func buildTree(leaves []*Node) *MerkleTree {
 		}
 
 		nodes = level
+		levels = append(levels, nodes)
 	}
 
-	return &MerkleTree{Root: nodes[0], Leaves: leaves}
+	return &MerkleTree{Root: nodes[0], Leaves: leaves, levels: levels, rfc6962: rfc6962}
 }
 
+// VerifyProof checks leaf against proof and rootHash. It consults
+// proof.RFC6962 so a proof generated against an RFC6962 tree can't be
+// verified against a root computed without domain separation, or vice versa.
 func VerifyProof(leaf []byte, proof *MerkleProof, rootHash [32]byte) bool {
-	hash, i := HashFn(leaf), proof.i
+	hash, i := leafHash(leaf, proof.RFC6962), proof.I
 
 	// If the proof only has one hash and index is more than 0 than that means the index had no sibling until the root.
 	// Since this index is even it will always be the right child of the root. Consider the following example
@@ -146,14 +321,14 @@ func VerifyProof(leaf []byte, proof *MerkleProof, rootHash [32]byte) bool {
 	//       \   /
 	//       ABCDE
 	if len(proof.Hashes) == 1 && i > 0 {
-		return rootHash == HashFn(append(proof.Hashes[0][:], hash[:]...))
+		return rootHash == nodeHash(proof.Hashes[0], hash, proof.RFC6962)
 	}
 
 	for _, siblingHash := range proof.Hashes {
 		if i%2 == 0 {
-			hash = HashFn(append(hash[:], siblingHash[:]...))
+			hash = nodeHash(hash, siblingHash, proof.RFC6962)
 		} else {
-			hash = HashFn(append(siblingHash[:], hash[:]...))
+			hash = nodeHash(siblingHash, hash, proof.RFC6962)
 		}
 		i /= 2
 	}
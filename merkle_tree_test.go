@@ -45,7 +45,7 @@ func TestAddLeaf(t *testing.T) {
 	leaves := [][]byte{[]byte("l1"), []byte("l2"), []byte("l3"), []byte("l4"), []byte("l5")}
 	tree := merkleTree.New(leaves)
 	newLeaf := []byte("newLeaf")
-	tree.AddLeaf(newLeaf)
+	require.NoError(t, tree.AddLeaf(newLeaf))
 
 	assert.Equal(t, CalculateMerkleRoot(append(leaves, newLeaf)), tree.RootHash())
 }
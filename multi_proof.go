@@ -0,0 +1,143 @@
+package merkle_tree
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrNoIndices = errors.New("no indices given")
+
+// MultiProof authenticates several leaves against the same root at once. It
+// carries only the sibling hashes a verifier can't derive itself: whenever
+// two requested leaves' paths meet at a shared ancestor, that ancestor is
+// recomputed instead of transmitted, so the proof grows roughly with
+// log(NumLeaves) + len(indices) rather than len(indices)*log(NumLeaves).
+type MultiProof struct {
+	NumLeaves int
+	Hashes    [][32]byte
+	RFC6962   bool
+}
+
+// GenerateMultiProof builds a MultiProof for the given leaf indices. It
+// walks the tree level by level, tracking which node positions the verifier
+// will already know (either because they were requested, or because both
+// of a pair's children are already known), and emits a sibling hash only
+// for positions the verifier can't otherwise derive.
+func (m *MerkleTree) GenerateMultiProof(indices []int) (*MultiProof, error) {
+	if err := m.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	if len(indices) == 0 {
+		return nil, ErrNoIndices
+	}
+
+	sorted := append([]int{}, indices...)
+	sort.Ints(sorted)
+
+	known := make(map[int]bool, len(sorted))
+	for _, i := range sorted {
+		if i < 0 || i >= len(m.Leaves) {
+			return nil, ErrIndexOutOfRange
+		}
+		known[i] = true
+	}
+
+	nodes := m.Leaves
+	var hashes [][32]byte
+	for len(nodes) > 1 {
+		nextKnown := make(map[int]bool)
+		var level []*Node
+
+		for j := 0; j < len(nodes)-1; j += 2 {
+			parentHash := nodeHash(nodes[j].Hash, nodes[j+1].Hash, m.rfc6962)
+			level = append(level, &Node{Hash: parentHash, Left: nodes[j], Right: nodes[j+1]})
+			parentIdx := len(level) - 1
+
+			switch jKnown, j1Known := known[j], known[j+1]; {
+			case jKnown && j1Known:
+				nextKnown[parentIdx] = true
+			case jKnown:
+				hashes = append(hashes, nodes[j+1].Hash)
+				nextKnown[parentIdx] = true
+			case j1Known:
+				hashes = append(hashes, nodes[j].Hash)
+				nextKnown[parentIdx] = true
+			}
+		}
+
+		// If the nodes at any level are odd then append the node as is.
+		if len(nodes)%2 == 1 {
+			level = append(level, nodes[len(nodes)-1])
+			if known[len(nodes)-1] {
+				nextKnown[len(level)-1] = true
+			}
+		}
+
+		nodes = level
+		known = nextKnown
+	}
+
+	return &MultiProof{NumLeaves: len(m.Leaves), Hashes: hashes, RFC6962: m.rfc6962}, nil
+}
+
+// VerifyMultiProof checks leaves (keyed by their original index) against
+// proof and rootHash, mirroring GenerateMultiProof's known-position
+// bookkeeping level by level.
+func VerifyMultiProof(leaves map[int][]byte, proof *MultiProof, rootHash [32]byte) bool {
+	if len(leaves) == 0 {
+		return false
+	}
+
+	hashes := make(map[int][32]byte, len(leaves))
+	for i, leaf := range leaves {
+		if i < 0 || i >= proof.NumLeaves {
+			return false
+		}
+		hashes[i] = leafHash(leaf, proof.RFC6962)
+	}
+
+	remaining := proof.Hashes
+	n := proof.NumLeaves
+	for n > 1 {
+		nextHashes := make(map[int][32]byte)
+		parentIdx := 0
+
+		for j := 0; j < n-1; j += 2 {
+			left, leftKnown := hashes[j]
+			right, rightKnown := hashes[j+1]
+
+			switch {
+			case leftKnown && rightKnown:
+			case leftKnown:
+				if len(remaining) == 0 {
+					return false
+				}
+				right, remaining = remaining[0], remaining[1:]
+			case rightKnown:
+				if len(remaining) == 0 {
+					return false
+				}
+				left, remaining = remaining[0], remaining[1:]
+			default:
+				parentIdx++
+				continue
+			}
+
+			nextHashes[parentIdx] = nodeHash(left, right, proof.RFC6962)
+			parentIdx++
+		}
+
+		if n%2 == 1 {
+			if h, ok := hashes[n-1]; ok {
+				nextHashes[parentIdx] = h
+			}
+			parentIdx++
+		}
+
+		n = parentIdx
+		hashes = nextHashes
+	}
+
+	root, ok := hashes[0]
+	return ok && len(remaining) == 0 && root == rootHash
+}
@@ -0,0 +1,67 @@
+package merkle_tree_test
+
+import (
+	"fmt"
+	"testing"
+
+	merkleTree "github.com/IronGauntlets/merkle-tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndVerifyMultiProof(t *testing.T) {
+	var leaves [][]byte
+	for i := 0; i < 11; i++ {
+		leaves = append(leaves, []byte(fmt.Sprintf("leaf-%d", i)))
+	}
+	tree := merkleTree.New(leaves)
+
+	t.Run("verifies a clustered set of indices", func(t *testing.T) {
+		indices := []int{2, 3, 4, 7}
+		proof, err := tree.GenerateMultiProof(indices)
+		require.NoError(t, err)
+
+		requested := make(map[int][]byte)
+		for _, i := range indices {
+			requested[i] = leaves[i]
+		}
+		assert.True(t, merkleTree.VerifyMultiProof(requested, proof, tree.RootHash()))
+	})
+
+	t.Run("verifies a scattered set of indices in any order", func(t *testing.T) {
+		indices := []int{10, 0, 5}
+		proof, err := tree.GenerateMultiProof(indices)
+		require.NoError(t, err)
+
+		requested := map[int][]byte{10: leaves[10], 0: leaves[0], 5: leaves[5]}
+		assert.True(t, merkleTree.VerifyMultiProof(requested, proof, tree.RootHash()))
+	})
+
+	t.Run("fails when a leaf's value is wrong", func(t *testing.T) {
+		indices := []int{1, 9}
+		proof, err := tree.GenerateMultiProof(indices)
+		require.NoError(t, err)
+
+		requested := map[int][]byte{1: []byte("tampered"), 9: leaves[9]}
+		assert.False(t, merkleTree.VerifyMultiProof(requested, proof, tree.RootHash()))
+	})
+
+	t.Run("fails against the wrong root", func(t *testing.T) {
+		indices := []int{0, 1}
+		proof, err := tree.GenerateMultiProof(indices)
+		require.NoError(t, err)
+
+		requested := map[int][]byte{0: leaves[0], 1: leaves[1]}
+		assert.False(t, merkleTree.VerifyMultiProof(requested, proof, merkleTree.HashFn([]byte("wrong"))))
+	})
+
+	t.Run("errors on an out-of-range index", func(t *testing.T) {
+		_, err := tree.GenerateMultiProof([]int{len(leaves)})
+		assert.ErrorIs(t, err, merkleTree.ErrIndexOutOfRange)
+	})
+
+	t.Run("errors when no indices are given", func(t *testing.T) {
+		_, err := tree.GenerateMultiProof(nil)
+		assert.ErrorIs(t, err, merkleTree.ErrNoIndices)
+	})
+}
@@ -0,0 +1,161 @@
+package merkle_tree
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrProofIndexOutOfRange = errors.New("proof index out of range")
+
+// pendingSubtree is one slot of the height-indexed stack used by
+// buildFromReader: pending[h] holds the root of a complete subtree of
+// 2^h leaves that hasn't yet found an equal-height match to merge with, or
+// nil if no such subtree exists at that height. This mirrors the
+// "stack of subtree roots keyed by height, merge on equal height" approach
+// used by Sia/NebulousLabs merkletree, and produces the exact same root
+// buildTree would for the same leaves, since merging two equal-height
+// subtrees whenever possible is just an incremental form of buildTree's
+// left-to-right pairing, and folding the leftover stack from the lowest
+// height up reproduces its odd-promotion rule.
+type pendingSubtree struct {
+	hash     [32]byte
+	isTarget bool
+}
+
+// buildFromReader chunks r into segmentSize-byte leaves (the last one may be
+// short), hashing and merging them incrementally so memory stays O(log n)
+// in the number of leaves. When trackProof is true, it also collects the
+// authentication path for the leaf at proofIndex. opts selects the hashing
+// scheme, exactly as NewWithOptions does for in-memory trees.
+func buildFromReader(r io.Reader, segmentSize int, proofIndex uint64, trackProof bool, opts Options) (root [32]byte, proof *MerkleProof, numLeaves uint64, err error) {
+	var pending []*pendingSubtree
+	var hashes [][32]byte
+	var bits []uint
+
+	buf := make([]byte, segmentSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n == 0 {
+			if readErr == io.EOF {
+				break
+			}
+			return [32]byte{}, nil, 0, readErr
+		}
+
+		cur := &pendingSubtree{
+			hash:     leafHash(buf[:n], opts.RFC6962),
+			isTarget: trackProof && numLeaves == proofIndex,
+		}
+		numLeaves++
+
+		height := 0
+		for {
+			if height == len(pending) {
+				pending = append(pending, cur)
+				break
+			}
+			if pending[height] == nil {
+				pending[height] = cur
+				break
+			}
+
+			left, right := pending[height], cur
+			if left.isTarget {
+				hashes = append(hashes, right.hash)
+				bits = append(bits, 0)
+			} else if right.isTarget {
+				hashes = append(hashes, left.hash)
+				bits = append(bits, 1)
+			}
+
+			cur = &pendingSubtree{
+				hash:     nodeHash(left.hash, right.hash, opts.RFC6962),
+				isTarget: left.isTarget || right.isTarget,
+			}
+			pending[height] = nil
+			height++
+		}
+
+		if readErr == io.ErrUnexpectedEOF || readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return [32]byte{}, nil, 0, readErr
+		}
+	}
+
+	if numLeaves == 0 {
+		return [32]byte{}, nil, 0, nil
+	}
+	if trackProof && proofIndex >= numLeaves {
+		return [32]byte{}, nil, 0, ErrProofIndexOutOfRange
+	}
+
+	// Fold the leftover stack from the lowest height up, the streaming
+	// equivalent of buildTree's rule for promoting an unpaired node to the
+	// next level.
+	var carry *pendingSubtree
+	for h := 0; h < len(pending); h++ {
+		if pending[h] == nil {
+			continue
+		}
+		if carry == nil {
+			carry = pending[h]
+			continue
+		}
+
+		left, right := pending[h], carry
+		if left.isTarget {
+			hashes = append(hashes, right.hash)
+			bits = append(bits, 0)
+		} else if right.isTarget {
+			hashes = append(hashes, left.hash)
+			bits = append(bits, 1)
+		}
+
+		carry = &pendingSubtree{
+			hash:     nodeHash(left.hash, right.hash, opts.RFC6962),
+			isTarget: left.isTarget || right.isTarget,
+		}
+	}
+
+	if !trackProof {
+		return carry.hash, nil, numLeaves, nil
+	}
+
+	var i int
+	for k, b := range bits {
+		i |= int(b) << uint(k)
+	}
+	return carry.hash, &MerkleProof{I: i, Hashes: hashes, RFC6962: opts.RFC6962}, numLeaves, nil
+}
+
+// NewFromReader computes a tree's root by chunking r into segmentSize-byte
+// leaves and combining them incrementally, using O(log n) memory instead of
+// holding every leaf as New does. It produces the same root New would for
+// the equivalent leaves.
+func NewFromReader(r io.Reader, segmentSize int) (root [32]byte, numLeaves uint64, err error) {
+	return NewFromReaderWithOptions(r, segmentSize, Options{})
+}
+
+// NewFromReaderWithOptions is like NewFromReader but lets the caller
+// configure the hashing scheme, e.g. Options{RFC6962: true}, exactly as
+// NewWithOptions does for in-memory trees.
+func NewFromReaderWithOptions(r io.Reader, segmentSize int, opts Options) (root [32]byte, numLeaves uint64, err error) {
+	root, _, numLeaves, err = buildFromReader(r, segmentSize, 0, false, opts)
+	return root, numLeaves, err
+}
+
+// BuildReaderProof is like NewFromReader but also builds the authentication
+// proof for the leaf at proofIndex, without ever holding the whole tree in
+// memory. It's the natural way to prove membership in a large file or blob
+// that doesn't fit comfortably in memory as a [][]byte of leaves.
+func BuildReaderProof(r io.Reader, segmentSize int, proofIndex uint64) (root [32]byte, proof *MerkleProof, numLeaves uint64, err error) {
+	return BuildReaderProofWithOptions(r, segmentSize, proofIndex, Options{})
+}
+
+// BuildReaderProofWithOptions is like BuildReaderProof but lets the caller
+// configure the hashing scheme, e.g. Options{RFC6962: true}.
+func BuildReaderProofWithOptions(r io.Reader, segmentSize int, proofIndex uint64, opts Options) (root [32]byte, proof *MerkleProof, numLeaves uint64, err error) {
+	return buildFromReader(r, segmentSize, proofIndex, true, opts)
+}
@@ -0,0 +1,65 @@
+package merkle_tree_test
+
+import (
+	"bytes"
+	"testing"
+
+	merkleTree "github.com/IronGauntlets/merkle-tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromReader(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog, twice over")
+	segmentSize := 8
+
+	var leaves [][]byte
+	for i := 0; i < len(data); i += segmentSize {
+		end := i + segmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+		leaves = append(leaves, data[i:end])
+	}
+
+	tree := merkleTree.New(leaves)
+	require.NotNil(t, tree)
+
+	root, numLeaves, err := merkleTree.NewFromReader(bytes.NewReader(data), segmentSize)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(len(leaves)), numLeaves)
+	assert.Equal(t, tree.RootHash(), root)
+}
+
+func TestBuildReaderProof(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog, twice over")
+	segmentSize := 8
+
+	var leaves [][]byte
+	for i := 0; i < len(data); i += segmentSize {
+		end := i + segmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+		leaves = append(leaves, data[i:end])
+	}
+
+	for i := range leaves {
+		root, proof, numLeaves, err := merkleTree.BuildReaderProof(bytes.NewReader(data), segmentSize, uint64(i))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(len(leaves)), numLeaves)
+		assert.True(t, merkleTree.VerifyProof(leaves[i], proof, root))
+	}
+
+	t.Run("out of range proof index errors", func(t *testing.T) {
+		_, _, _, err := merkleTree.BuildReaderProof(bytes.NewReader(data), segmentSize, uint64(len(leaves)))
+		assert.ErrorIs(t, err, merkleTree.ErrProofIndexOutOfRange)
+	})
+
+	t.Run("empty reader produces the zero root", func(t *testing.T) {
+		root, numLeaves, err := merkleTree.NewFromReader(bytes.NewReader(nil), segmentSize)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), numLeaves)
+		assert.Equal(t, [32]byte{}, root)
+	})
+}
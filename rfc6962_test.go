@@ -0,0 +1,97 @@
+package merkle_tree_test
+
+import (
+	"bytes"
+	"testing"
+
+	merkleTree "github.com/IronGauntlets/merkle-tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithOptionsRFC6962(t *testing.T) {
+	leaves := [][]byte{[]byte("l1"), []byte("l2"), []byte("l3"), []byte("l4"), []byte("l5")}
+
+	t.Run("root differs from the non-domain-separated tree", func(t *testing.T) {
+		plain := merkleTree.New(leaves)
+		domainSeparated := merkleTree.NewWithOptions(leaves, merkleTree.Options{RFC6962: true})
+		assert.NotEqual(t, plain.RootHash(), domainSeparated.RootHash())
+	})
+
+	t.Run("proofs generated in RFC6962 mode verify against the RFC6962 root", func(t *testing.T) {
+		tree := merkleTree.NewWithOptions(leaves, merkleTree.Options{RFC6962: true})
+
+		for i, leaf := range leaves {
+			proof, err := tree.GenerateProof(i)
+			require.NoError(t, err)
+			assert.True(t, merkleTree.VerifyProof(leaf, proof, tree.RootHash()))
+		}
+	})
+
+	t.Run("a proof from one mode does not verify against the other mode's root", func(t *testing.T) {
+		plain := merkleTree.New(leaves)
+		domainSeparated := merkleTree.NewWithOptions(leaves, merkleTree.Options{RFC6962: true})
+
+		proof, err := domainSeparated.GenerateProof(0)
+		require.NoError(t, err)
+		assert.False(t, merkleTree.VerifyProof(leaves[0], proof, plain.RootHash()))
+
+		proof.RFC6962 = false
+		assert.False(t, merkleTree.VerifyProof(leaves[0], proof, domainSeparated.RootHash()))
+	})
+
+	t.Run("AddLeaf and UpdateLeaf keep hashing in RFC6962 mode", func(t *testing.T) {
+		tree := merkleTree.NewWithOptions(leaves[:4], merkleTree.Options{RFC6962: true})
+
+		require.NoError(t, tree.AddLeaf(leaves[4]))
+		rebuilt := merkleTree.NewWithOptions(leaves, merkleTree.Options{RFC6962: true})
+		assert.Equal(t, rebuilt.RootHash(), tree.RootHash())
+
+		require.NoError(t, tree.UpdateLeaf(0, []byte("updated")))
+		proof, err := tree.GenerateProof(0)
+		require.NoError(t, err)
+		assert.True(t, merkleTree.VerifyProof([]byte("updated"), proof, tree.RootHash()))
+	})
+
+	t.Run("multi-proofs respect RFC6962 mode", func(t *testing.T) {
+		tree := merkleTree.NewWithOptions(leaves, merkleTree.Options{RFC6962: true})
+
+		indices := []int{0, 2, 4}
+		proof, err := tree.GenerateMultiProof(indices)
+		require.NoError(t, err)
+
+		requested := map[int][]byte{0: leaves[0], 2: leaves[2], 4: leaves[4]}
+		assert.True(t, merkleTree.VerifyMultiProof(requested, proof, tree.RootHash()))
+	})
+
+	t.Run("reader-built trees respect RFC6962 mode", func(t *testing.T) {
+		data := []byte("the quick brown fox jumps over the lazy dog, twice over")
+		segmentSize := 8
+
+		var readerLeaves [][]byte
+		for i := 0; i < len(data); i += segmentSize {
+			end := i + segmentSize
+			if end > len(data) {
+				end = len(data)
+			}
+			readerLeaves = append(readerLeaves, data[i:end])
+		}
+
+		tree := merkleTree.NewWithOptions(readerLeaves, merkleTree.Options{RFC6962: true})
+
+		root, numLeaves, err := merkleTree.NewFromReaderWithOptions(bytes.NewReader(data), segmentSize, merkleTree.Options{RFC6962: true})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(len(readerLeaves)), numLeaves)
+		assert.Equal(t, tree.RootHash(), root)
+
+		plainRoot, _, err := merkleTree.NewFromReader(bytes.NewReader(data), segmentSize)
+		require.NoError(t, err)
+		assert.NotEqual(t, plainRoot, root)
+
+		for i, leaf := range readerLeaves {
+			proofRoot, proof, _, err := merkleTree.BuildReaderProofWithOptions(bytes.NewReader(data), segmentSize, uint64(i), merkleTree.Options{RFC6962: true})
+			require.NoError(t, err)
+			assert.True(t, merkleTree.VerifyProof(leaf, proof, proofRoot))
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package merkle_tree
+
+import "errors"
+
+var ErrReachedMaxLevel = errors.New("reached max level")
+
+var ErrKeyNotFound = errors.New("key not found")
+
+// smtLeaf is a key/value binding stored at the end of a path through the
+// sparse tree. Its hash is H(Key || ValueHash).
+type smtLeaf struct {
+	Key       [32]byte
+	ValueHash [32]byte
+}
+
+func (l *smtLeaf) hash() [32]byte {
+	return HashFn(append(append([]byte{}, l.Key[:]...), l.ValueHash[:]...))
+}
+
+// smtInterior is a branch node on the path to a leaf. Its hash is
+// H(LeftHash || RightHash); a zero hash on either side means that subtree is
+// empty.
+type smtInterior struct {
+	LeftHash  [32]byte
+	RightHash [32]byte
+}
+
+func (n *smtInterior) hash() [32]byte {
+	return HashFn(append(append([]byte{}, n.LeftHash[:]...), n.RightHash[:]...))
+}
+
+// bitAt returns the i-th bit of hash, counting from the most significant bit
+// of byte 0. It is used to turn a key's hash into the left/right path
+// through the tree.
+func bitAt(hash [32]byte, i int) int {
+	byteIndex := i / 8
+	bitIndex := 7 - uint(i%8)
+	return int((hash[byteIndex] >> bitIndex) & 1)
+}
+
+// SparseMerkleTree stores key -> value bindings rather than a positional
+// leaf list. A key is hashed with HashFn and the bits of that hash are used
+// as the path from Root down to the leaf holding the value, so the tree has
+// the same shape no matter the order keys were inserted in.
+type SparseMerkleTree struct {
+	Root      [32]byte
+	MaxLevels int
+
+	leaves    map[[32]byte]*smtLeaf
+	interiors map[[32]byte]*smtInterior
+}
+
+// NewSparseMerkleTree returns an empty tree whose paths are at most
+// maxLevels long; Insert and Delete return ErrReachedMaxLevel if a key's
+// path would need to go deeper than that.
+func NewSparseMerkleTree(maxLevels int) *SparseMerkleTree {
+	return &SparseMerkleTree{
+		MaxLevels: maxLevels,
+		leaves:    make(map[[32]byte]*smtLeaf),
+		interiors: make(map[[32]byte]*smtInterior),
+	}
+}
+
+func (t *SparseMerkleTree) storeInterior(left, right [32]byte) [32]byte {
+	node := &smtInterior{LeftHash: left, RightHash: right}
+	hash := node.hash()
+	t.interiors[hash] = node
+	return hash
+}
+
+// Insert binds key to value, replacing any existing value for that key.
+func (t *SparseMerkleTree) Insert(key, value []byte) error {
+	leaf := &smtLeaf{Key: HashFn(key), ValueHash: HashFn(value)}
+
+	root, err := t.insert(t.Root, leaf, 0)
+	if err != nil {
+		return err
+	}
+
+	t.Root = root
+	return nil
+}
+
+func (t *SparseMerkleTree) insert(nodeHash [32]byte, newLeaf *smtLeaf, level int) ([32]byte, error) {
+	if level > t.MaxLevels {
+		return [32]byte{}, ErrReachedMaxLevel
+	}
+
+	if nodeHash == ([32]byte{}) {
+		t.leaves[newLeaf.hash()] = newLeaf
+		return newLeaf.hash(), nil
+	}
+
+	if existing, ok := t.leaves[nodeHash]; ok {
+		if existing.Key == newLeaf.Key {
+			t.leaves[newLeaf.hash()] = newLeaf
+			return newLeaf.hash(), nil
+		}
+		return t.pushDown(existing, newLeaf, level)
+	}
+
+	interior := t.interiors[nodeHash]
+	if bitAt(newLeaf.Key, level) == 0 {
+		child, err := t.insert(interior.LeftHash, newLeaf, level+1)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		return t.storeInterior(child, interior.RightHash), nil
+	}
+
+	child, err := t.insert(interior.RightHash, newLeaf, level+1)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return t.storeInterior(interior.LeftHash, child), nil
+}
+
+// pushDown walks existing and newLeaf down the tree one bit at a time,
+// creating single-child interiors while their keys still share a prefix,
+// until the bits diverge and both leaves can hang off the same interior.
+// The interior it creates sits at level, so its leaves end up at level+1.
+func (t *SparseMerkleTree) pushDown(existing, newLeaf *smtLeaf, level int) ([32]byte, error) {
+	if level >= t.MaxLevels {
+		return [32]byte{}, ErrReachedMaxLevel
+	}
+
+	existingBit, newBit := bitAt(existing.Key, level), bitAt(newLeaf.Key, level)
+	if existingBit == newBit {
+		child, err := t.pushDown(existing, newLeaf, level+1)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		if existingBit == 0 {
+			return t.storeInterior(child, [32]byte{}), nil
+		}
+		return t.storeInterior([32]byte{}, child), nil
+	}
+
+	t.leaves[existing.hash()] = existing
+	t.leaves[newLeaf.hash()] = newLeaf
+	if existingBit == 0 {
+		return t.storeInterior(existing.hash(), newLeaf.hash()), nil
+	}
+	return t.storeInterior(newLeaf.hash(), existing.hash()), nil
+}
+
+// Delete removes key from the tree. Once its leaf is gone, any single-child
+// interior left dangling on the way up is collapsed: a remaining sibling
+// that is a leaf replaces it directly, repeating for as many levels as stay
+// collapsible, while a remaining sibling that is a multi-level subtree stays
+// at its depth under a single-child interior.
+func (t *SparseMerkleTree) Delete(key []byte) error {
+	k := HashFn(key)
+
+	type step struct {
+		sibling [32]byte
+		left    bool
+	}
+
+	var path []step
+	nodeHash := t.Root
+	for level := 0; ; level++ {
+		if nodeHash == ([32]byte{}) {
+			return ErrKeyNotFound
+		}
+		if leaf, ok := t.leaves[nodeHash]; ok {
+			if leaf.Key != k {
+				return ErrKeyNotFound
+			}
+			break
+		}
+		if level >= t.MaxLevels {
+			return ErrReachedMaxLevel
+		}
+
+		interior := t.interiors[nodeHash]
+		if bitAt(k, level) == 0 {
+			path = append(path, step{sibling: interior.RightHash, left: true})
+			nodeHash = interior.LeftHash
+		} else {
+			path = append(path, step{sibling: interior.LeftHash, left: false})
+			nodeHash = interior.RightHash
+		}
+	}
+
+	current := [32]byte{}
+	currentIsLeaf := false
+	for i := len(path) - 1; i >= 0; i-- {
+		sibling := path[i].sibling
+
+		switch {
+		case current == [32]byte{} && sibling == [32]byte{}:
+			// Both sides still empty: keep forwarding the collapse up.
+		case current == [32]byte{}:
+			if _, ok := t.leaves[sibling]; ok {
+				// A leaf's hash doesn't depend on the depth it's found at,
+				// so it can replace this node directly.
+				current, currentIsLeaf = sibling, true
+			} else if path[i].left {
+				// The sibling is itself a multi-level subtree: its interior
+				// nodes were built assuming their depth, so wrap it in a
+				// single-child interior here instead of hoisting it, or
+				// every bitAt lookup inside it would shift by a level.
+				current = t.storeInterior([32]byte{}, sibling)
+			} else {
+				current = t.storeInterior(sibling, [32]byte{})
+			}
+		case currentIsLeaf && sibling == [32]byte{}:
+			// current is still a bare leaf and this level's other side is
+			// empty too: keep forwarding it unwrapped, since a leaf's hash
+			// doesn't depend on depth either.
+		case path[i].left:
+			current, currentIsLeaf = t.storeInterior(current, sibling), false
+		default:
+			current, currentIsLeaf = t.storeInterior(sibling, current), false
+		}
+	}
+
+	t.Root = current
+	return nil
+}
+
+// SparseLeaf is the key/value binding an AuthenticationPath terminates at.
+// For an inclusion proof its Key matches the hash the proof was requested
+// for; for a non-inclusion proof it is the leaf found where that key would
+// have been, or nil if that subtree was empty.
+type SparseLeaf struct {
+	Key       [32]byte
+	ValueHash [32]byte
+}
+
+// AuthenticationPath proves either that a key is bound to a value
+// (Included) or that it isn't in the tree, by carrying the sibling hashes
+// from the root down to wherever the key's path ends.
+type AuthenticationPath struct {
+	Key      [32]byte
+	Siblings [][32]byte
+	Leaf     *SparseLeaf
+	Included bool
+}
+
+// Get returns the authentication path for key, whether or not it is bound
+// to a value.
+func (t *SparseMerkleTree) Get(key []byte) (*AuthenticationPath, error) {
+	k := HashFn(key)
+	path := &AuthenticationPath{Key: k}
+
+	nodeHash := t.Root
+	for level := 0; level <= t.MaxLevels; level++ {
+		if nodeHash == ([32]byte{}) {
+			return path, nil
+		}
+
+		if leaf, ok := t.leaves[nodeHash]; ok {
+			path.Leaf = &SparseLeaf{Key: leaf.Key, ValueHash: leaf.ValueHash}
+			path.Included = leaf.Key == k
+			return path, nil
+		}
+
+		interior := t.interiors[nodeHash]
+		if bitAt(k, level) == 0 {
+			path.Siblings = append(path.Siblings, interior.RightHash)
+			nodeHash = interior.LeftHash
+		} else {
+			path.Siblings = append(path.Siblings, interior.LeftHash)
+			nodeHash = interior.RightHash
+		}
+	}
+
+	return nil, ErrReachedMaxLevel
+}
+
+// recompute replays the interior hashing Insert and Delete use, starting
+// from nodeHash (the terminal leaf's hash, or the zero hash for an empty
+// subtree) and folding in p.Siblings from the deepest level back to the
+// root.
+func (p *AuthenticationPath) recompute(nodeHash [32]byte) [32]byte {
+	for level := len(p.Siblings) - 1; level >= 0; level-- {
+		sibling := p.Siblings[level]
+		node := &smtInterior{}
+		if bitAt(p.Key, level) == 0 {
+			node.LeftHash, node.RightHash = nodeHash, sibling
+		} else {
+			node.LeftHash, node.RightHash = sibling, nodeHash
+		}
+		nodeHash = node.hash()
+	}
+	return nodeHash
+}
+
+// VerifySparseProof checks an inclusion proof (key bound to value) or,
+// when value is nil, a non-inclusion proof (key not bound to anything) for
+// the given root.
+func VerifySparseProof(key, value []byte, proof *AuthenticationPath, root [32]byte) bool {
+	if proof == nil || HashFn(key) != proof.Key {
+		return false
+	}
+	if proof.Included != (value != nil) {
+		return false
+	}
+
+	if proof.Included {
+		if proof.Leaf == nil || proof.Leaf.Key != proof.Key || proof.Leaf.ValueHash != HashFn(value) {
+			return false
+		}
+		leaf := smtLeaf{Key: proof.Leaf.Key, ValueHash: proof.Leaf.ValueHash}
+		return proof.recompute(leaf.hash()) == root
+	}
+
+	var terminal [32]byte
+	if proof.Leaf != nil {
+		if proof.Leaf.Key == proof.Key {
+			return false
+		}
+		leaf := smtLeaf{Key: proof.Leaf.Key, ValueHash: proof.Leaf.ValueHash}
+		terminal = leaf.hash()
+	}
+	return proof.recompute(terminal) == root
+}
@@ -0,0 +1,149 @@
+package merkle_tree_test
+
+import (
+	"fmt"
+	"testing"
+
+	merkleTree "github.com/IronGauntlets/merkle-tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseMerkleTreeInsertAndGet(t *testing.T) {
+	keys := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+
+	t.Run("inserted keys verify as included", func(t *testing.T) {
+		tree := merkleTree.NewSparseMerkleTree(256)
+		for i, k := range keys {
+			require.NoError(t, tree.Insert([]byte(k), []byte(fmt.Sprintf("val-%d", i))))
+		}
+
+		for i, k := range keys {
+			proof, err := tree.Get([]byte(k))
+			require.NoError(t, err)
+			assert.True(t, proof.Included)
+			assert.True(t, merkleTree.VerifySparseProof([]byte(k), []byte(fmt.Sprintf("val-%d", i)), proof, tree.Root))
+			assert.False(t, merkleTree.VerifySparseProof([]byte(k), []byte("wrong-value"), proof, tree.Root))
+		}
+	})
+
+	t.Run("missing key verifies as non-inclusion", func(t *testing.T) {
+		tree := merkleTree.NewSparseMerkleTree(256)
+		for i, k := range keys {
+			require.NoError(t, tree.Insert([]byte(k), []byte(fmt.Sprintf("val-%d", i))))
+		}
+
+		proof, err := tree.Get([]byte("not-there"))
+		require.NoError(t, err)
+		assert.False(t, proof.Included)
+		assert.True(t, merkleTree.VerifySparseProof([]byte("not-there"), nil, proof, tree.Root))
+	})
+
+	t.Run("re-inserting a key updates its value", func(t *testing.T) {
+		tree := merkleTree.NewSparseMerkleTree(256)
+		require.NoError(t, tree.Insert([]byte("alpha"), []byte("v1")))
+		require.NoError(t, tree.Insert([]byte("alpha"), []byte("v2")))
+
+		proof, err := tree.Get([]byte("alpha"))
+		require.NoError(t, err)
+		assert.True(t, merkleTree.VerifySparseProof([]byte("alpha"), []byte("v2"), proof, tree.Root))
+		assert.False(t, merkleTree.VerifySparseProof([]byte("alpha"), []byte("v1"), proof, tree.Root))
+	})
+
+	t.Run("root does not depend on insertion order", func(t *testing.T) {
+		t1 := merkleTree.NewSparseMerkleTree(256)
+		t2 := merkleTree.NewSparseMerkleTree(256)
+
+		for i, k := range keys {
+			require.NoError(t, t1.Insert([]byte(k), []byte(fmt.Sprintf("val-%d", i))))
+		}
+		for i := len(keys) - 1; i >= 0; i-- {
+			require.NoError(t, t2.Insert([]byte(keys[i]), []byte(fmt.Sprintf("val-%d", i))))
+		}
+
+		assert.Equal(t, t1.Root, t2.Root)
+	})
+}
+
+func TestSparseMerkleTreeDelete(t *testing.T) {
+	keys := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+
+	t.Run("deleted key becomes non-inclusion and others stay valid", func(t *testing.T) {
+		tree := merkleTree.NewSparseMerkleTree(256)
+		for i, k := range keys {
+			require.NoError(t, tree.Insert([]byte(k), []byte(fmt.Sprintf("val-%d", i))))
+		}
+
+		require.NoError(t, tree.Delete([]byte("gamma")))
+
+		proof, err := tree.Get([]byte("gamma"))
+		require.NoError(t, err)
+		assert.False(t, proof.Included)
+		assert.True(t, merkleTree.VerifySparseProof([]byte("gamma"), nil, proof, tree.Root))
+
+		for i, k := range keys {
+			if k == "gamma" {
+				continue
+			}
+			proof, err := tree.Get([]byte(k))
+			require.NoError(t, err)
+			assert.True(t, proof.Included)
+			assert.True(t, merkleTree.VerifySparseProof([]byte(k), []byte(fmt.Sprintf("val-%d", i)), proof, tree.Root))
+		}
+	})
+
+	t.Run("deleting down to one key matches a tree built with only that key", func(t *testing.T) {
+		tree := merkleTree.NewSparseMerkleTree(256)
+		require.NoError(t, tree.Insert([]byte("alpha"), []byte("v1")))
+		require.NoError(t, tree.Insert([]byte("beta"), []byte("v2")))
+		require.NoError(t, tree.Delete([]byte("beta")))
+
+		solo := merkleTree.NewSparseMerkleTree(256)
+		require.NoError(t, solo.Insert([]byte("alpha"), []byte("v1")))
+
+		assert.Equal(t, solo.Root, tree.Root)
+	})
+
+	t.Run("error if key is not present", func(t *testing.T) {
+		tree := merkleTree.NewSparseMerkleTree(256)
+		require.NoError(t, tree.Insert([]byte("alpha"), []byte("v1")))
+
+		assert.ErrorIs(t, tree.Delete([]byte("nope")), merkleTree.ErrKeyNotFound)
+	})
+
+	t.Run("collapsing a deletion whose sibling is a multi-level subtree doesn't corrupt other keys", func(t *testing.T) {
+		// 200 keys fill the top levels of the tree, so deletions frequently
+		// collapse against a sibling that's itself a multi-level subtree
+		// rather than a lone leaf; a maxLevels of 16 is still deep enough
+		// for every one of these keys.
+		tree := merkleTree.NewSparseMerkleTree(16)
+
+		numKeys := 200
+		keyFor := func(i int) []byte { return []byte(fmt.Sprintf("key-%d", i)) }
+		valueFor := func(i int) []byte { return []byte(fmt.Sprintf("val-%d", i)) }
+
+		for i := 0; i < numKeys; i++ {
+			require.NoError(t, tree.Insert(keyFor(i), valueFor(i)))
+		}
+
+		deleted := make(map[int]bool)
+		for i := 0; i < numKeys; i += 2 {
+			require.NoError(t, tree.Delete(keyFor(i)))
+			deleted[i] = true
+		}
+
+		for i := 0; i < numKeys; i++ {
+			proof, err := tree.Get(keyFor(i))
+			require.NoError(t, err)
+
+			if deleted[i] {
+				assert.False(t, proof.Included)
+				assert.True(t, merkleTree.VerifySparseProof(keyFor(i), nil, proof, tree.Root))
+				continue
+			}
+
+			assert.True(t, proof.Included)
+			assert.True(t, merkleTree.VerifySparseProof(keyFor(i), valueFor(i), proof, tree.Root))
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package merkle_tree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"sync"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Storage persists tree nodes keyed by their hash, so a MerkleTree can be
+// built once and reloaded later (possibly by a different process) without
+// keeping every node in memory.
+type Storage interface {
+	Get(hash [32]byte) ([]byte, error)
+	Put(hash [32]byte, data []byte) error
+	Delete(hash [32]byte) error
+}
+
+// MemoryStorage is the default Storage: it keeps nodes in a map, matching
+// the tree's original in-memory-only behavior.
+type MemoryStorage struct {
+	mu   sync.RWMutex
+	data map[[32]byte][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{data: make(map[[32]byte][]byte)}
+}
+
+func (s *MemoryStorage) Get(hash [32]byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.data[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *MemoryStorage) Put(hash [32]byte, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[hash] = data
+	return nil
+}
+
+func (s *MemoryStorage) Delete(hash [32]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, hash)
+	return nil
+}
+
+// storedNode is the gob-encoded form of a Node. A zero Left and Right means
+// the node is a leaf, since buildTree never hashes a real node to the zero
+// value. NumLeaves is the count of leaves under the node, recorded so a
+// path lookup can tell which child holds a given leaf index without
+// fetching either child's subtree.
+type storedNode struct {
+	Left      [32]byte
+	Right     [32]byte
+	NumLeaves int
+}
+
+func childHash(n *Node) [32]byte {
+	if n == nil {
+		return [32]byte{}
+	}
+	return n.Hash
+}
+
+func encodeNode(n *Node, numLeaves int) ([]byte, error) {
+	var buf bytes.Buffer
+	sn := storedNode{Left: childHash(n.Left), Right: childHash(n.Right), NumLeaves: numLeaves}
+	if err := gob.NewEncoder(&buf).Encode(sn); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeNode(data []byte) (storedNode, error) {
+	var sn storedNode
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&sn)
+	return sn, err
+}
+
+// persistTree writes n and every descendant to s, keyed by hash, and
+// returns how many leaves are under n so an ancestor can record that count
+// too. Nodes shared between subtrees are written more than once but with
+// identical content, so they dedupe naturally under their shared key.
+func persistTree(s Storage, n *Node) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+
+	numLeaves := 1
+	if n.Left != nil || n.Right != nil {
+		leftLeaves, err := persistTree(s, n.Left)
+		if err != nil {
+			return 0, err
+		}
+		rightLeaves, err := persistTree(s, n.Right)
+		if err != nil {
+			return 0, err
+		}
+		numLeaves = leftLeaves + rightLeaves
+	}
+
+	data, err := encodeNode(n, numLeaves)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.Put(n.Hash, data); err != nil {
+		return 0, err
+	}
+	return numLeaves, nil
+}
+
+// NewWithStorage builds a tree exactly like New, then persists every node
+// to s so it can later be reconstructed with Load.
+func NewWithStorage(leaves [][]byte, s Storage) (*MerkleTree, error) {
+	return NewWithStorageOptions(leaves, s, Options{})
+}
+
+// NewWithStorageOptions is like NewWithStorage but lets the caller
+// configure the hashing scheme, e.g. Options{RFC6962: true}, exactly as
+// NewWithOptions does for in-memory trees.
+func NewWithStorageOptions(leaves [][]byte, s Storage, opts Options) (*MerkleTree, error) {
+	tree := NewWithOptions(leaves, opts)
+	if tree == nil {
+		return nil, nil
+	}
+
+	if _, err := persistTree(s, tree.Root); err != nil {
+		return nil, err
+	}
+	tree.storage = s
+	return tree, nil
+}
+
+// Load reconstructs a tree from storage given its root hash. The tree
+// starts as a bare Root stub; Leaves are only fetched from s the first time
+// a traversal such as UpdateLeaf or RemoveLeaf needs the whole leaf set;
+// GenerateProof instead fetches only the nodes on the requested leaf's path.
+func Load(s Storage, rootHash [32]byte) *MerkleTree {
+	return LoadWithOptions(s, rootHash, Options{})
+}
+
+// LoadWithOptions is like Load but lets the caller specify the hashing
+// scheme the persisted tree was built with, e.g. Options{RFC6962: true},
+// so mutations and proofs keep using the same rule it was persisted with.
+func LoadWithOptions(s Storage, rootHash [32]byte, opts Options) *MerkleTree {
+	return &MerkleTree{Root: &Node{Hash: rootHash}, storage: s, rfc6962: opts.RFC6962}
+}
+
+// ensureLoaded fetches Leaves (and rebuilds the level cache) from storage
+// the first time they're needed, so a tree returned by Load behaves like
+// one built in memory for every call after the first.
+func (m *MerkleTree) ensureLoaded() error {
+	if m.Leaves != nil || m.storage == nil {
+		return nil
+	}
+
+	leaves, err := m.collectLeaves(m.Root)
+	if err != nil {
+		return err
+	}
+
+	m.Leaves = leaves
+	rebuilt := buildTree(leaves, m.rfc6962)
+	m.levels = rebuilt.levels
+	return nil
+}
+
+func (m *MerkleTree) collectLeaves(n *Node) ([]*Node, error) {
+	data, err := m.storage.Get(n.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sn, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if sn.Left == ([32]byte{}) && sn.Right == ([32]byte{}) {
+		return []*Node{n}, nil
+	}
+
+	left, err := m.collectLeaves(&Node{Hash: sn.Left})
+	if err != nil {
+		return nil, err
+	}
+	right, err := m.collectLeaves(&Node{Hash: sn.Right})
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// generateProofFromStorage builds leaf i's membership proof by descending
+// only the O(log n) nodes on its path, fetching each from storage as it
+// goes, instead of materializing every leaf the way ensureLoaded does.
+func (m *MerkleTree) generateProofFromStorage(i int) (*MerkleProof, error) {
+	node, err := m.decodeStored(m.Root.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= node.NumLeaves {
+		return nil, ErrIndexOutOfRange
+	}
+
+	proof := &MerkleProof{I: i, RFC6962: m.rfc6962}
+	for node.Left != ([32]byte{}) || node.Right != ([32]byte{}) {
+		left, err := m.decodeStored(node.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		if i < left.NumLeaves {
+			proof.Hashes = append(proof.Hashes, node.Right)
+			node = left
+			continue
+		}
+
+		i -= left.NumLeaves
+		proof.Hashes = append(proof.Hashes, node.Left)
+		if node, err = m.decodeStored(node.Right); err != nil {
+			return nil, err
+		}
+	}
+
+	// Hashes were collected root-to-leaf; VerifyProof walks leaf-to-root.
+	for l, r := 0, len(proof.Hashes)-1; l < r; l, r = l+1, r-1 {
+		proof.Hashes[l], proof.Hashes[r] = proof.Hashes[r], proof.Hashes[l]
+	}
+	return proof, nil
+}
+
+func (m *MerkleTree) decodeStored(hash [32]byte) (storedNode, error) {
+	data, err := m.storage.Get(hash)
+	if err != nil {
+		return storedNode{}, err
+	}
+	return decodeNode(data)
+}
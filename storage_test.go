@@ -0,0 +1,138 @@
+package merkle_tree_test
+
+import (
+	"testing"
+
+	merkleTree "github.com/IronGauntlets/merkle-tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithStorageAndLoad(t *testing.T) {
+	leaves := [][]byte{[]byte("l1"), []byte("l2"), []byte("l3"), []byte("l4"), []byte("l5")}
+
+	t.Run("loaded tree has the same root as the original", func(t *testing.T) {
+		storage := merkleTree.NewMemoryStorage()
+		tree, err := merkleTree.NewWithStorage(leaves, storage)
+		require.NoError(t, err)
+
+		loaded := merkleTree.Load(storage, tree.RootHash())
+		assert.Equal(t, tree.RootHash(), loaded.RootHash())
+	})
+
+	t.Run("proofs generated from a loaded tree verify", func(t *testing.T) {
+		storage := merkleTree.NewMemoryStorage()
+		tree, err := merkleTree.NewWithStorage(leaves, storage)
+		require.NoError(t, err)
+
+		loaded := merkleTree.Load(storage, tree.RootHash())
+		for i, leaf := range leaves {
+			proof, err := loaded.GenerateProof(i)
+			require.NoError(t, err)
+			assert.True(t, merkleTree.VerifyProof(leaf, proof, loaded.RootHash()))
+		}
+	})
+
+	t.Run("loading an unknown root fails to resolve its children", func(t *testing.T) {
+		storage := merkleTree.NewMemoryStorage()
+		loaded := merkleTree.Load(storage, [32]byte{0xFF})
+
+		_, err := loaded.GenerateProof(0)
+		assert.Error(t, err)
+	})
+
+	t.Run("AddLeaf on a freshly loaded tree keeps the existing leaves", func(t *testing.T) {
+		storage := merkleTree.NewMemoryStorage()
+		tree, err := merkleTree.NewWithStorage(leaves, storage)
+		require.NoError(t, err)
+
+		loaded := merkleTree.Load(storage, tree.RootHash())
+		newLeaf := []byte("l6")
+		require.NoError(t, loaded.AddLeaf(newLeaf))
+
+		want := merkleTree.New(append(append([][]byte{}, leaves...), newLeaf))
+		assert.Equal(t, want.RootHash(), loaded.RootHash())
+	})
+
+	t.Run("UpdateLeaf and RemoveLeaf lazily load a freshly loaded tree instead of erroring", func(t *testing.T) {
+		storage := merkleTree.NewMemoryStorage()
+		tree, err := merkleTree.NewWithStorage(leaves, storage)
+		require.NoError(t, err)
+
+		loaded := merkleTree.Load(storage, tree.RootHash())
+		updatedLeaf := []byte("updated")
+		require.NoError(t, loaded.UpdateLeaf(1, updatedLeaf))
+
+		want := merkleTree.New([][]byte{leaves[0], updatedLeaf, leaves[2], leaves[3], leaves[4]})
+		assert.Equal(t, want.RootHash(), loaded.RootHash())
+
+		reloaded := merkleTree.Load(storage, tree.RootHash())
+		require.NoError(t, reloaded.RemoveLeaf(0))
+
+		wantAfterRemove := merkleTree.New(leaves[1:])
+		assert.Equal(t, wantAfterRemove.RootHash(), reloaded.RootHash())
+	})
+
+	t.Run("GenerateProof on a loaded tree only fetches the path to the leaf", func(t *testing.T) {
+		backing := merkleTree.NewMemoryStorage()
+		tree, err := merkleTree.NewWithStorage(leaves, backing)
+		require.NoError(t, err)
+
+		counting := &countingStorage{Storage: backing}
+		loaded := merkleTree.Load(counting, tree.RootHash())
+
+		proof, err := loaded.GenerateProof(2)
+		require.NoError(t, err)
+		assert.True(t, merkleTree.VerifyProof(leaves[2], proof, loaded.RootHash()))
+
+		// Fetching every node in this 5-leaf tree would take 9 Gets; a
+		// single path should take far fewer.
+		assert.LessOrEqual(t, counting.gets, 6)
+	})
+
+	t.Run("NewWithStorageOptions and LoadWithOptions round-trip RFC6962 mode", func(t *testing.T) {
+		storage := merkleTree.NewMemoryStorage()
+		tree, err := merkleTree.NewWithStorageOptions(leaves, storage, merkleTree.Options{RFC6962: true})
+		require.NoError(t, err)
+
+		loaded := merkleTree.LoadWithOptions(storage, tree.RootHash(), merkleTree.Options{RFC6962: true})
+		for i, leaf := range leaves {
+			proof, err := loaded.GenerateProof(i)
+			require.NoError(t, err)
+			assert.True(t, proof.RFC6962)
+			assert.True(t, merkleTree.VerifyProof(leaf, proof, loaded.RootHash()))
+		}
+
+		plain := merkleTree.New(leaves)
+		assert.NotEqual(t, plain.RootHash(), loaded.RootHash())
+	})
+}
+
+// countingStorage wraps a Storage and counts Get calls, so tests can assert
+// a lookup only touched the O(log n) nodes it needed.
+type countingStorage struct {
+	merkleTree.Storage
+	gets int
+}
+
+func (s *countingStorage) Get(hash [32]byte) ([]byte, error) {
+	s.gets++
+	return s.Storage.Get(hash)
+}
+
+func TestMemoryStorage(t *testing.T) {
+	storage := merkleTree.NewMemoryStorage()
+	hash := merkleTree.HashFn([]byte("data"))
+
+	_, err := storage.Get(hash)
+	assert.ErrorIs(t, err, merkleTree.ErrNotFound)
+
+	require.NoError(t, storage.Put(hash, []byte("payload")))
+	data, err := storage.Get(hash)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), data)
+
+	require.NoError(t, storage.Delete(hash))
+	_, err = storage.Get(hash)
+	assert.ErrorIs(t, err, merkleTree.ErrNotFound)
+}